@@ -2,13 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/myprizepicks/releasechannels-server-poc/internal/db"
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
 	"github.com/myprizepicks/releasechannels-server-poc/internal/server"
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const inFile = "db/db.json"
@@ -17,44 +28,153 @@ const inFile = "db/db.json"
 var Version = "dev"
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	gl := zerolog.New(os.Stderr).With().Timestamp().Logger()
-	ll := gl.With().Str("package", "main").Logger()
+	storageDriver := flag.String("storage", envOr("STORAGE_DRIVER", "memory"), "storage driver to use (memory, boltdb, configmap)")
+	storagePath := flag.String("storage-path", envOr("STORAGE_PATH", "db/releases.boltdb"), "file path for the boltdb driver")
+	storageNamespace := flag.String("storage-namespace", envOr("STORAGE_NAMESPACE", "default"), "namespace for the configmap driver")
+	storageName := flag.String("storage-name", envOr("STORAGE_NAME", "releasechannels"), "configmap name for the configmap driver")
+	grpcAddr := flag.String("grpc-addr", envOr("GRPC_ADDR", ":8090"), "address the ReleaseChannels gRPC service listens on")
+	tlsEnabled := flag.Bool("tls", envOr("TLS", "false") == "true", "serve HTTP and gRPC over TLS")
+	tlsCert := flag.String("tls-cert", envOr("TLS_CERT", ""), "path to the TLS certificate (PEM)")
+	tlsKey := flag.String("tls-key", envOr("TLS_KEY", ""), "path to the TLS private key (PEM)")
+	tlsVerify := flag.Bool("tls-verify", envOr("TLS_VERIFY", "false") == "true", "verify client certs against --tls-ca (mTLS) on write endpoints")
+	tlsCA := flag.String("tls-ca", envOr("TLS_CA", ""), "path to the CA bundle used to verify client certs")
+	historyCap := flag.String("history-cap", envOr("HISTORY_CAP", ""), "number of prior values to retain per release key (defaults to db.DefaultHistoryCap)")
+	logFormat := flag.String("log-format", envOr("LOG_FORMAT", "json"), "log output format: json, text, or slog")
+	flag.Parse()
+
+	// ctx is the single root context shared by srv.Run and runGRPC, so a
+	// SIGINT/SIGTERM (or a sibling's errgroup failure) stops both transports
+	// instead of leaving the other hanging on a context only it was given.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	gl, err := newLogger(*logFormat)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	ll := gl.With("package", "main")
 
 	// Log the version on startup
-	ll.Info().Str("version", Version).Msg("starting release channels server")
+	ll.Info("starting release channels server", "version", Version, "storage_driver", *storageDriver)
 
 	eg, ctx := errgroup.WithContext(ctx)
 
+	// Build the storage driver, selected via --storage/STORAGE_DRIVER
+	storageCfg := map[string]string{
+		"path":       *storagePath,
+		"namespace":  *storageNamespace,
+		"name":       *storageName,
+		"historyCap": *historyCap,
+	}
+	store, err := db.New(*storageDriver, storageCfg, gl)
+	if err != nil {
+		ll.Error("error constructing storage driver", "error", err)
+		os.Exit(2)
+	}
+
 	// Open our db
-	db, err := os.Open(inFile)
+	dbFile, err := os.Open(inFile)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer db.Close()
-	ll.Debug().Msg("db file opened")
+	defer dbFile.Close()
+	ll.Debug("db file opened")
 
 	// read in the data
-	bytes, err := io.ReadAll(db)
+	bytes, err := io.ReadAll(dbFile)
 	if err != nil {
-		ll.Error().Err(err).Msg("error starting server")
+		ll.Error("error starting server", "error", err)
 		os.Exit(2)
 	}
 
 	// create the server
-	srv, err := server.New(bytes, inFile, &gl)
+	srv, err := server.New(store, bytes, inFile, gl)
 	if err != nil {
-		ll.Error().Err(err).Msg("error starting server")
+		ll.Error("error starting server", "error", err)
 		os.Exit(2)
 	}
 
-	// start the server
+	// build the shared tls.Config, if --tls is enabled
+	var tlsConfig *tls.Config
+	if *tlsEnabled {
+		tlsConfig, err = server.NewTLSConfig(*tlsCert, *tlsKey, *tlsCA, *tlsVerify)
+		if err != nil {
+			ll.Error("error building tls config", "error", err)
+			os.Exit(2)
+		}
+		srv.SetTLSConfig(tlsConfig)
+	}
+
+	// start the HTTP server
 	eg.Go(func() error {
 		return srv.Run(ctx)
 	})
 
+	// start the gRPC server, sharing the same db.Store and tls.Config via srv
+	eg.Go(func() error {
+		return runGRPC(ctx, srv, *grpcAddr, tlsConfig, ll)
+	})
+
 	eg.Wait()
 }
+
+// runGRPC starts the ReleaseChannels gRPC service on addr and blocks until
+// ctx is cancelled, at which point it stops the server gracefully. When
+// tlsConfig is non-nil, the gRPC listener reuses it, so TLS/mTLS settings
+// apply consistently to both transports.
+func runGRPC(ctx context.Context, srv server.Server, addr string, tlsConfig *tls.Config, ll log.Logger) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(srv.UnaryInterceptor())}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	gs := grpc.NewServer(opts...)
+	srv.RegisterGRPC(gs)
+
+	errChan := make(chan error, 1)
+	go func() {
+		ll.Info("grpc server listening", "addr", addr)
+		errChan <- gs.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+		ll.Info("grpc server stopping")
+		gs.GracefulStop()
+		return nil
+	case err := <-errChan:
+		return err
+	}
+}
+
+// envOr returns the named environment variable, or def if it is unset or empty.
+func envOr(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok && strings.TrimSpace(v) != "" {
+		return v
+	}
+	return def
+}
+
+// newLogger builds a log.Logger for the given --log-format: "json" and
+// "text" both use the zerolog adapter (a console writer for "text"),
+// "slog" uses the standard library's log/slog adapter instead.
+func newLogger(format string) (log.Logger, error) {
+	switch format {
+	case "json":
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		return log.NewZerolog(zerolog.New(os.Stderr).With().Timestamp().Logger()), nil
+	case "text":
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		writer := zerolog.ConsoleWriter{Out: os.Stderr}
+		return log.NewZerolog(zerolog.New(writer).With().Timestamp().Logger()), nil
+	case "slog":
+		return log.NewSlog(slog.New(slog.NewTextHandler(os.Stderr, nil))), nil
+	default:
+		return nil, fmt.Errorf("unknown --log-format %q (want json, text, or slog)", format)
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+// releasesBucket is the single bucket a boltStore keeps its data in. The
+// full Releases slice is kept under releasesKey and reindexed into memory
+// on open, since the release set is small enough that per-key indexing on
+// disk buys nothing over indexing in RAM and persisting the flat list.
+const (
+	releasesBucket = "releases"
+	releasesKey    = "releases"
+)
+
+// boltHistoryBucket is one ReleaseKey's ring buffer, persisted as a list
+// rather than keyed by ReleaseKey.String() so the key's fields round-trip
+// exactly even if they contain "/". Shared with configMapStore, whose
+// persisted shape is identical.
+type boltHistoryBucket struct {
+	Container      string         `json:"container"`
+	ReleaseChannel string         `json:"releaseChannel"`
+	Entries        []historyEntry `json:"entries"`
+}
+
+// boltSnapshot is the JSON shape persisted under releasesKey: the flat
+// release list plus the per-key history ring buffers.
+type boltSnapshot struct {
+	Releases Releases            `json:"releases"`
+	History  []boltHistoryBucket `json:"history"`
+}
+
+// boltStore implements Store on top of a BoltDB file so state survives
+// process restarts. bolt.Open locks the file exclusively, so only one
+// process can hold the store open at a time; a second opener fails once
+// the open timeout runs out.
+// The in-memory view lives in ix, rebuilt from the bucket on open and kept
+// in sync on every Write so Query stays as cheap as the in-memory driver.
+type boltStore struct {
+	db *bolt.DB
+	ix *releaseIndex
+	ll log.Logger
+}
+
+// boltStore implements Store
+var _ Store = &boltStore{}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path and
+// returns a Store backed by it, retaining DefaultHistoryCap prior values
+// per ReleaseKey.
+func NewBoltStore(path string, gl log.Logger) (Store, error) {
+	return NewBoltStoreWithHistoryCap(path, DefaultHistoryCap, gl)
+}
+
+// NewBoltStoreWithHistoryCap opens (creating if necessary) a BoltDB file at
+// path and returns a Store backed by it, retaining up to historyCap prior
+// values per ReleaseKey.
+func NewBoltStoreWithHistoryCap(path string, historyCap int, gl log.Logger) (Store, error) {
+	ll := gl.With("package", "db", "driver", "boltdb")
+
+	bdb, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening boltdb at %s: %w", path, err)
+	}
+
+	bs := &boltStore{
+		db: bdb,
+		ix: newReleaseIndex(ll, historyCap),
+		ll: ll,
+	}
+
+	if err := bs.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(releasesBucket))
+		return err
+	}); err != nil {
+		bdb.Close()
+		return nil, fmt.Errorf("creating bucket %s: %w", releasesBucket, err)
+	}
+
+	if err := bs.reindex(); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	bs.ll.Info("boltdb store opened", "path", path, "releases_loaded", bs.ix.len())
+	return bs, nil
+}
+
+// reindex loads the persisted snapshot and rebuilds the in-memory index.
+func (bs *boltStore) reindex() error {
+	var snap boltSnapshot
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(releasesBucket))
+		raw := b.Get([]byte(releasesKey))
+		if raw == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, &snap)
+	})
+	if err != nil {
+		return fmt.Errorf("reindexing boltdb store: %w", err)
+	}
+
+	history := make(map[ReleaseKey][]historyEntry, len(snap.History))
+	for _, hb := range snap.History {
+		key := ReleaseKey{Container: hb.Container, ReleaseChannel: hb.ReleaseChannel}
+		history[key] = hb.Entries
+	}
+	bs.ix.reload(snap.Releases, history)
+	return nil
+}
+
+// persist writes the current in-memory state back to the bucket.
+func (bs *boltStore) persist() error {
+	releases, buckets := bs.ix.snapshot()
+	snap := boltSnapshot{Releases: releases, History: buckets}
+
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("marshalling releases: %w", err)
+	}
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(releasesBucket))
+		return b.Put([]byte(releasesKey), raw)
+	})
+}
+
+// Query searches the Store for matching releases
+func (bs *boltStore) Query(r *Release) Releases { return bs.ix.query(r) }
+
+// Write adds a new release to the Store and persists it to disk.
+func (bs *boltStore) Write(r *Release) error {
+	if err := bs.ix.write(r); err != nil {
+		return err
+	}
+	if err := bs.persist(); err != nil {
+		bs.ll.Error("error persisting release to boltdb", "error", err)
+		return err
+	}
+	return nil
+}
+
+// History returns up to limit prior values of key, newest first.
+func (bs *boltStore) History(key ReleaseKey, limit int) Releases { return bs.ix.history(key, limit) }
+
+// Rollback replaces key's current value with the value from steps writes
+// ago, persists the change, and returns it.
+func (bs *boltStore) Rollback(key ReleaseKey, steps int) (*Release, error) {
+	target, err := bs.ix.rollback(key, steps)
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.persist(); err != nil {
+		bs.ll.Error("error persisting rollback to boltdb", "error", err)
+		return nil, err
+	}
+	return target, nil
+}
+
+func init() {
+	Register("boltdb", func(cfg map[string]string, gl log.Logger) (Store, error) {
+		path := cfg["path"]
+		if path == "" {
+			path = "db/releases.boltdb"
+		}
+		historyCap := DefaultHistoryCap
+		if raw, ok := cfg["historyCap"]; ok {
+			if n, err := strconv.Atoi(raw); err == nil {
+				historyCap = n
+			}
+		}
+		return NewBoltStoreWithHistoryCap(path, historyCap, gl)
+	})
+}
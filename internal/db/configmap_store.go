@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// configMapDataKey is the key under which the serialized Releases list is
+// stored in the backing ConfigMap's Data map.
+const configMapDataKey = "releases.json"
+
+// configMapHistoryKey is the key under which the serialized per-key
+// history ring buffers are stored in the backing ConfigMap's Data map.
+const configMapHistoryKey = "history.json"
+
+// configMapStore implements Store by reading/writing a single Kubernetes
+// ConfigMap, so multiple server replicas in the same namespace can share
+// state without a dedicated database. The in-memory view lives in ix.
+type configMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	ll        log.Logger
+	ix        *releaseIndex
+}
+
+// configMapStore implements Store
+var _ Store = &configMapStore{}
+
+// NewConfigMapStore returns a Store backed by the ConfigMap name in
+// namespace, using in-cluster config to talk to the API server, retaining
+// DefaultHistoryCap prior values per ReleaseKey.
+func NewConfigMapStore(namespace, name string, gl log.Logger) (Store, error) {
+	return NewConfigMapStoreWithHistoryCap(namespace, name, DefaultHistoryCap, gl)
+}
+
+// NewConfigMapStoreWithHistoryCap returns a Store backed by the ConfigMap
+// name in namespace, retaining up to historyCap prior values per
+// ReleaseKey.
+func NewConfigMapStoreWithHistoryCap(namespace, name string, historyCap int, gl log.Logger) (Store, error) {
+	ll := gl.With("package", "db", "driver", "configmap")
+
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading in-cluster config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building kubernetes client: %w", err)
+	}
+
+	cms := &configMapStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		ll:        ll,
+		ix:        newReleaseIndex(ll, historyCap),
+	}
+
+	if err := cms.ensureConfigMap(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := cms.reindex(context.Background()); err != nil {
+		return nil, err
+	}
+	cms.ll.Info("configmap store opened", "namespace", namespace, "name", name, "releases_loaded", cms.ix.len())
+	return cms, nil
+}
+
+// ensureConfigMap creates the backing ConfigMap if it does not already exist.
+func (cms *configMapStore) ensureConfigMap(ctx context.Context) error {
+	_, err := cms.client.CoreV1().ConfigMaps(cms.namespace).Get(ctx, cms.name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("getting configmap %s/%s: %w", cms.namespace, cms.name, err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cms.name,
+			Namespace: cms.namespace,
+		},
+		Data: map[string]string{configMapDataKey: "[]", configMapHistoryKey: "[]"},
+	}
+	_, err = cms.client.CoreV1().ConfigMaps(cms.namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("creating configmap %s/%s: %w", cms.namespace, cms.name, err)
+	}
+	return nil
+}
+
+// reindex re-reads the ConfigMap and rebuilds the in-memory index.
+func (cms *configMapStore) reindex(ctx context.Context) error {
+	cm, err := cms.client.CoreV1().ConfigMaps(cms.namespace).Get(ctx, cms.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting configmap %s/%s: %w", cms.namespace, cms.name, err)
+	}
+
+	var releases Releases
+	if raw, ok := cm.Data[configMapDataKey]; ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &releases); err != nil {
+			return fmt.Errorf("unmarshalling configmap data: %w", err)
+		}
+	}
+
+	var historyBuckets []boltHistoryBucket
+	if raw, ok := cm.Data[configMapHistoryKey]; ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &historyBuckets); err != nil {
+			return fmt.Errorf("unmarshalling configmap history: %w", err)
+		}
+	}
+
+	history := make(map[ReleaseKey][]historyEntry, len(historyBuckets))
+	for _, hb := range historyBuckets {
+		key := ReleaseKey{Container: hb.Container, ReleaseChannel: hb.ReleaseChannel}
+		history[key] = hb.Entries
+	}
+	cms.ix.reload(releases, history)
+	return nil
+}
+
+// persist writes the current in-memory state back to the ConfigMap.
+func (cms *configMapStore) persist(ctx context.Context) error {
+	releases, buckets := cms.ix.snapshot()
+
+	rawReleases, err := json.Marshal(releases)
+	if err != nil {
+		return fmt.Errorf("marshalling releases: %w", err)
+	}
+	rawHistory, err := json.Marshal(buckets)
+	if err != nil {
+		return fmt.Errorf("marshalling history: %w", err)
+	}
+
+	cm, err := cms.client.CoreV1().ConfigMaps(cms.namespace).Get(ctx, cms.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting configmap %s/%s: %w", cms.namespace, cms.name, err)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[configMapDataKey] = string(rawReleases)
+	cm.Data[configMapHistoryKey] = string(rawHistory)
+
+	_, err = cms.client.CoreV1().ConfigMaps(cms.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("updating configmap %s/%s: %w", cms.namespace, cms.name, err)
+	}
+	return nil
+}
+
+// Query searches the Store for matching releases
+func (cms *configMapStore) Query(r *Release) Releases { return cms.ix.query(r) }
+
+// Write adds a new release to the Store and persists it to the ConfigMap.
+// Other replicas may be writing to the same ConfigMap concurrently, so the
+// write is retried against a freshly re-read copy whenever the Update is
+// rejected for a stale ResourceVersion.
+func (cms *configMapStore) Write(r *Release) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cms.reindex(context.Background()); err != nil {
+			return err
+		}
+		if err := cms.ix.write(r); err != nil {
+			return err
+		}
+		return cms.persist(context.Background())
+	})
+	if err != nil {
+		cms.ll.Error("error persisting release to configmap", "error", err)
+		return err
+	}
+	return nil
+}
+
+// History returns up to limit prior values of key, newest first.
+func (cms *configMapStore) History(key ReleaseKey, limit int) Releases {
+	return cms.ix.history(key, limit)
+}
+
+// Rollback replaces key's current value with the value from steps writes
+// ago, persists the change, and returns it. Like Write, it re-reads and
+// reapplies against the latest ConfigMap state on every retry, so a
+// concurrent write from another replica doesn't get silently clobbered.
+func (cms *configMapStore) Rollback(key ReleaseKey, steps int) (*Release, error) {
+	var target *Release
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cms.reindex(context.Background()); err != nil {
+			return err
+		}
+		t, err := cms.ix.rollback(key, steps)
+		if err != nil {
+			return err
+		}
+		target = t
+		return cms.persist(context.Background())
+	})
+	if err != nil {
+		cms.ll.Error("error persisting rollback to configmap", "error", err)
+		return nil, err
+	}
+	return target, nil
+}
+
+func init() {
+	Register("configmap", func(cfg map[string]string, gl log.Logger) (Store, error) {
+		namespace := cfg["namespace"]
+		if namespace == "" {
+			namespace = "default"
+		}
+		name := cfg["name"]
+		if name == "" {
+			name = "releasechannels"
+		}
+		historyCap := DefaultHistoryCap
+		if raw, ok := cfg["historyCap"]; ok {
+			if n, err := strconv.Atoi(raw); err == nil {
+				historyCap = n
+			}
+		}
+		return NewConfigMapStoreWithHistoryCap(namespace, name, historyCap, gl)
+	})
+}
@@ -2,14 +2,28 @@ package db
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
-	"github.com/rs/zerolog"
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
 )
 
+// DefaultHistoryCap is the default number of prior values retained per
+// ReleaseKey when a driver doesn't configure its own cap.
+const DefaultHistoryCap = 10
+
 // Store is the interface for a releasechan api data-store
 type Store interface {
 	Query(r *Release) Releases
 	Write(r *Release) error
+	// History returns up to limit prior values of the release at key,
+	// newest first. limit <= 0 means "no limit" (capped by the driver's
+	// retention window regardless).
+	History(key ReleaseKey, limit int) Releases
+	// Rollback replaces the current value at key with the value from
+	// steps writes ago (steps == 1 is the immediately preceding value)
+	// and returns it.
+	Rollback(key ReleaseKey, steps int) (*Release, error)
 }
 
 // Release implements the formal definition of a software release
@@ -41,89 +55,59 @@ func (rk *ReleaseKey) String() string {
 	return fmt.Sprintf("%s/%s", rk.Container, rk.ReleaseChannel)
 }
 
-// inMem Implements Store in-memory
-type inMem struct {
-	all         Releases
-	byChannel   map[string]Releases
-	byContainer map[string]Releases
-	byBoth      map[ReleaseKey]*Release
-	ll          *zerolog.Logger
+// historyEntry pairs a past Release value with when it was superseded, so
+// the ring buffer can be pruned and inspected in insertion order.
+type historyEntry struct {
+	Release   *Release
+	Timestamp time.Time
 }
 
-// NewInMemoryStore is a constructor for an in-memory Store
-func NewInMemoryStore(gl *zerolog.Logger) Store {
-	ll := gl.With().Str("package", "db").Logger()
-	return &inMem{
-		all:         Releases{},
-		byChannel:   make(map[string]Releases),
-		byContainer: make(map[string]Releases),
-		byBoth:      make(map[ReleaseKey]*Release),
-		ll:          &ll,
-	}
+// inMem implements Store in-memory on top of a releaseIndex.
+type inMem struct {
+	ix *releaseIndex
 }
 
-// inMem implements store
-var _ Store = &inMem{}
-
-// Query searches the Store for matching releases
-func (im *inMem) Query(r *Release) Releases {
-	im.ll.Info().Str("container", r.Container).Str("releaseChannel", r.ReleaseChannel).Msg("New Query")
-
-	// first check by release key
-	if r.Container != "" && r.ReleaseChannel != "" {
-		if out, ok := im.byBoth[r.ToKey()]; ok {
-			return Releases{out}
-		}
-		im.ll.Info().Msgf("empty result in search byBoth for %s", r.ToKey())
-		return Releases{}
-	}
+// NewInMemoryStore is a constructor for an in-memory Store, retaining
+// DefaultHistoryCap prior values per ReleaseKey.
+func NewInMemoryStore(gl log.Logger) Store {
+	return NewInMemoryStoreWithHistoryCap(gl, DefaultHistoryCap)
+}
 
-	// then check by container
-	if r.Container != "" {
-		if out, ok := im.byContainer[r.Container]; ok {
-			return out
-		}
-		im.ll.Info().Msgf("empty result in search by container for %s", r.Container)
-		return Releases{}
-	}
+// NewInMemoryStoreWithHistoryCap is a constructor for an in-memory Store
+// that retains up to historyCap prior values per ReleaseKey.
+func NewInMemoryStoreWithHistoryCap(gl log.Logger, historyCap int) Store {
+	return &inMem{ix: newReleaseIndex(gl.With("package", "db"), historyCap)}
+}
 
-	// finally check by channel
-	if r.ReleaseChannel != "" {
-		if out, ok := im.byChannel[r.ReleaseChannel]; ok {
-			return out
+func init() {
+	// "memory" is the default driver and always available.
+	Register("memory", func(cfg map[string]string, gl log.Logger) (Store, error) {
+		historyCap := DefaultHistoryCap
+		if raw, ok := cfg["historyCap"]; ok {
+			if n, err := strconv.Atoi(raw); err == nil {
+				historyCap = n
+			}
 		}
-		im.ll.Info().Msgf("empty result in search by release channel for %s", r.ReleaseChannel)
-		return Releases{}
-	}
-	im.ll.Info().Msg("global message store dump")
-	return im.all
+		return NewInMemoryStoreWithHistoryCap(gl, historyCap), nil
+	})
 }
 
-// Write adds new releases to the Store
-func (im *inMem) Write(r *Release) error {
-	im.ll.Debug().Str("container", r.Container).Str("release_channel", r.ReleaseChannel).Str("image_path", r.ImagePath).Msg("new entry")
-	// sanity check the input
-	if r.Container == "" {
-		return fmt.Errorf("`container` not set on write for %s", r)
-	}
-	if r.ReleaseChannel == "" {
-		return fmt.Errorf("`releaseChannel` not set on write for %s", r)
-	}
-	if r.ImagePath == "" {
-		return fmt.Errorf("`image_path` not set on write for %s", r)
-	}
-
-	// append the all store
-	im.all = append(im.all, r)
+// inMem implements store
+var _ Store = &inMem{}
 
-	// append the container store
-	im.byContainer[r.Container] = append(im.byContainer[r.Container], r)
+// Query searches the Store for matching releases
+func (im *inMem) Query(r *Release) Releases { return im.ix.query(r) }
 
-	// append the release-channel store
-	im.byChannel[r.ReleaseChannel] = append(im.byChannel[r.ReleaseChannel], r)
+// Write adds new releases to the Store
+func (im *inMem) Write(r *Release) error { return im.ix.write(r) }
 
-	// append the both store
-	im.byBoth[r.ToKey()] = r
+// History returns up to limit prior values of key, newest first.
+func (im *inMem) History(key ReleaseKey, limit int) Releases { return im.ix.history(key, limit) }
 
-	return nil
+// Rollback replaces key's current value with the value from steps writes
+// ago and returns it. The current value is pushed onto the history buffer
+// in its place; entries the rollback steps over are kept, not discarded, so
+// History still reflects every past value.
+func (im *inMem) Rollback(key ReleaseKey, steps int) (*Release, error) {
+	return im.ix.rollback(key, steps)
 }
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+)
+
+// DriverFactory constructs a Store from a driver-specific config map. The
+// config keys are driver-defined (e.g. a boltdb driver expects "path", a
+// configmap driver expects "namespace"/"name").
+type DriverFactory func(cfg map[string]string, gl log.Logger) (Store, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]DriverFactory)
+)
+
+// Register registers a storage driver under name so it can later be
+// selected by New. Register panics if called twice with the same name,
+// mirroring the database/sql driver registration pattern.
+func Register(name string, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if factory == nil {
+		panic("db: Register factory is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("db: Register called twice for driver " + name)
+	}
+	drivers[name] = factory
+}
+
+// Drivers returns the sorted names of the currently registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New constructs a Store using the driver registered under name.
+func New(name string, cfg map[string]string, gl log.Logger) (Store, error) {
+	driversMu.RLock()
+	factory, ok := drivers[name]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("db: unknown storage driver %q (known drivers: %v)", name, Drivers())
+	}
+	return factory(cfg, gl)
+}
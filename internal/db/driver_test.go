@@ -0,0 +1,51 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+)
+
+func TestRegisterPanicsOnNilFactory(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a nil factory")
+		}
+	}()
+	Register("test-nil-factory", nil)
+}
+
+func TestRegisterPanicsOnDuplicateName(t *testing.T) {
+	factory := func(cfg map[string]string, gl log.Logger) (Store, error) {
+		return NewInMemoryStore(gl), nil
+	}
+	Register("test-dup-driver", factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on a duplicate driver name")
+		}
+	}()
+	Register("test-dup-driver", factory)
+}
+
+func TestNewUnknownDriver(t *testing.T) {
+	_, err := New("does-not-exist", nil, nopLogger{})
+	if err == nil {
+		t.Fatal("expected an error for an unknown driver")
+	}
+	if !strings.Contains(err.Error(), "does-not-exist") {
+		t.Fatalf("error %q does not name the unknown driver", err.Error())
+	}
+}
+
+func TestNewKnownDriver(t *testing.T) {
+	store, err := New("memory", map[string]string{}, nopLogger{})
+	if err != nil {
+		t.Fatalf("New(\"memory\"): %v", err)
+	}
+	if store == nil {
+		t.Fatal("New(\"memory\") returned a nil Store")
+	}
+}
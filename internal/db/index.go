@@ -0,0 +1,242 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+)
+
+// releaseIndex holds the Query/Write/History/Rollback logic shared by every
+// Store driver (memory, boltdb, configmap), guarded by a single RWMutex so a
+// fix or behavior change (and thread-safety) only has to happen once.
+// Persistent drivers hold a releaseIndex (their ix field) for their in-memory view and call
+// reload/snapshot under its lock to stay consistent with their backing
+// store.
+type releaseIndex struct {
+	mu sync.RWMutex
+
+	all         Releases
+	byChannel   map[string]Releases
+	byContainer map[string]Releases
+	byBoth      map[ReleaseKey]*Release
+	historyBuf  map[ReleaseKey][]historyEntry
+	historyCap  int
+
+	ll log.Logger
+}
+
+// newReleaseIndex returns an empty releaseIndex retaining up to historyCap
+// prior values per ReleaseKey (DefaultHistoryCap if historyCap <= 0).
+func newReleaseIndex(ll log.Logger, historyCap int) *releaseIndex {
+	if historyCap <= 0 {
+		historyCap = DefaultHistoryCap
+	}
+	return &releaseIndex{
+		all:         Releases{},
+		byChannel:   make(map[string]Releases),
+		byContainer: make(map[string]Releases),
+		byBoth:      make(map[ReleaseKey]*Release),
+		historyBuf:  make(map[ReleaseKey][]historyEntry),
+		historyCap:  historyCap,
+		ll:          ll,
+	}
+}
+
+// len returns the number of releases currently held in the index.
+func (ix *releaseIndex) len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.all)
+}
+
+// query searches the index for matching releases.
+func (ix *releaseIndex) query(r *Release) Releases {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ix.ll.Info("New Query", "container", r.Container, "releaseChannel", r.ReleaseChannel)
+
+	// first check by release key
+	if r.Container != "" && r.ReleaseChannel != "" {
+		key := r.ToKey()
+		if out, ok := ix.byBoth[key]; ok {
+			return Releases{out}
+		}
+		ix.ll.Info("empty result in search byBoth", "key", key.String())
+		return Releases{}
+	}
+
+	// then check by container
+	if r.Container != "" {
+		if out, ok := ix.byContainer[r.Container]; ok {
+			return out
+		}
+		ix.ll.Info("empty result in search by container", "container", r.Container)
+		return Releases{}
+	}
+
+	// finally check by channel
+	if r.ReleaseChannel != "" {
+		if out, ok := ix.byChannel[r.ReleaseChannel]; ok {
+			return out
+		}
+		ix.ll.Info("empty result in search by release channel", "releaseChannel", r.ReleaseChannel)
+		return Releases{}
+	}
+	ix.ll.Info("global message store dump")
+	return ix.all
+}
+
+// write adds r to the index, pushing any existing value at r's key onto its
+// history ring buffer first.
+func (ix *releaseIndex) write(r *Release) error {
+	ix.ll.Debug("new entry", "container", r.Container, "release_channel", r.ReleaseChannel, "image_path", r.ImagePath)
+	if r.Container == "" {
+		return fmt.Errorf("`container` not set on write for %s", r)
+	}
+	if r.ReleaseChannel == "" {
+		return fmt.Errorf("`releaseChannel` not set on write for %s", r)
+	}
+	if r.ImagePath == "" {
+		return fmt.Errorf("`image_path` not set on write for %s", r)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	key := r.ToKey()
+	if old, ok := ix.byBoth[key]; ok {
+		ix.pushHistoryLocked(key, old)
+	}
+
+	ix.all = append(ix.all, r)
+	ix.byContainer[r.Container] = append(ix.byContainer[r.Container], r)
+	ix.byChannel[r.ReleaseChannel] = append(ix.byChannel[r.ReleaseChannel], r)
+	ix.byBoth[key] = r
+	return nil
+}
+
+// pushHistoryLocked appends r to key's ring buffer, trimming the oldest
+// entries once historyCap is exceeded. Callers must hold ix.mu.
+func (ix *releaseIndex) pushHistoryLocked(key ReleaseKey, r *Release) {
+	buf := append(ix.historyBuf[key], historyEntry{Release: r, Timestamp: time.Now()})
+	if len(buf) > ix.historyCap {
+		buf = buf[len(buf)-ix.historyCap:]
+	}
+	ix.historyBuf[key] = buf
+}
+
+// history returns up to limit prior values of key, newest first.
+func (ix *releaseIndex) history(key ReleaseKey, limit int) Releases {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	buf := ix.historyBuf[key]
+	out := Releases{}
+	for i := len(buf) - 1; i >= 0; i-- {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		out = append(out, buf[i].Release)
+	}
+	return out
+}
+
+// rollback replaces key's current value with the value from steps writes
+// ago and returns it. The current value is pushed onto the history buffer
+// in its place; entries the rollback steps over are kept, not discarded, so
+// history still reflects every past value.
+func (ix *releaseIndex) rollback(key ReleaseKey, steps int) (*Release, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	remaining, target, err := rollbackHistory(ix.historyBuf[key], ix.byBoth[key], steps, ix.historyCap)
+	if err != nil {
+		return nil, fmt.Errorf("rolling back %s by %d steps: %w", &key, steps, err)
+	}
+	ix.historyBuf[key] = remaining
+
+	ix.all = append(ix.all, target)
+	ix.byContainer[target.Container] = append(ix.byContainer[target.Container], target)
+	ix.byChannel[target.ReleaseChannel] = append(ix.byChannel[target.ReleaseChannel], target)
+	ix.byBoth[key] = target
+
+	ix.ll.Info("rolled back release", "key", key.String(), "steps", steps)
+	return target, nil
+}
+
+// rollbackHistory computes the history buffer and target release for rolling
+// a key's current value (cur, which may be nil if the key has no current
+// value) back steps entries. buf is ordered oldest first. Unlike a naive
+// buf[:len(buf)-steps] truncation, every entry buf steps over is kept in the
+// returned buffer (with cur appended as the most recent entry), so rollback
+// never loses history; the result is trimmed to historyCap from the end.
+func rollbackHistory(buf []historyEntry, cur *Release, steps, historyCap int) ([]historyEntry, *Release, error) {
+	if steps <= 0 {
+		return nil, nil, fmt.Errorf("rollback steps must be positive, got %d", steps)
+	}
+	if steps > len(buf) {
+		return nil, nil, fmt.Errorf("not enough history: have %d", len(buf))
+	}
+
+	idx := len(buf) - steps
+	target := buf[idx].Release
+
+	remaining := make([]historyEntry, 0, len(buf))
+	remaining = append(remaining, buf[:idx]...)
+	remaining = append(remaining, buf[idx+1:]...)
+	if cur != nil {
+		remaining = append(remaining, historyEntry{Release: cur, Timestamp: time.Now()})
+	}
+	if historyCap > 0 && len(remaining) > historyCap {
+		remaining = remaining[len(remaining)-historyCap:]
+	}
+	return remaining, target, nil
+}
+
+// snapshot returns copies of the release list and history buckets suitable
+// for persisting to disk or a ConfigMap.
+func (ix *releaseIndex) snapshot() (Releases, []boltHistoryBucket) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	releases := make(Releases, len(ix.all))
+	copy(releases, ix.all)
+
+	buckets := make([]boltHistoryBucket, 0, len(ix.historyBuf))
+	for key, entries := range ix.historyBuf {
+		buckets = append(buckets, boltHistoryBucket{
+			Container:      key.Container,
+			ReleaseChannel: key.ReleaseChannel,
+			Entries:        entries,
+		})
+	}
+	return releases, buckets
+}
+
+// reload replaces the index's contents with releases/history, rebuilding the
+// byChannel/byContainer/byBoth indices. It's used by persistent drivers to
+// resync with their backing store (e.g. after another replica's write).
+func (ix *releaseIndex) reload(releases Releases, history map[ReleaseKey][]historyEntry) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	all := Releases{}
+	byChannel := make(map[string]Releases)
+	byContainer := make(map[string]Releases)
+	byBoth := make(map[ReleaseKey]*Release)
+	for _, r := range releases {
+		all = append(all, r)
+		byContainer[r.Container] = append(byContainer[r.Container], r)
+		byChannel[r.ReleaseChannel] = append(byChannel[r.ReleaseChannel], r)
+		byBoth[r.ToKey()] = r
+	}
+
+	ix.all = all
+	ix.byChannel = byChannel
+	ix.byContainer = byContainer
+	ix.byBoth = byBoth
+	ix.historyBuf = history
+}
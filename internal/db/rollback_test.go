@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+)
+
+// nopLogger discards everything; it lets tests construct a Store without
+// pulling in a real logging backend.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any)   {}
+func (nopLogger) Info(string, ...any)    {}
+func (nopLogger) Warn(string, ...any)    {}
+func (nopLogger) Error(string, ...any)   {}
+func (nopLogger) With(...any) log.Logger { return nopLogger{} }
+
+var _ log.Logger = nopLogger{}
+
+// TestRollbackMultiStepPreservesHistory ensures a rollback of more than one
+// step keeps every entry it steps over, instead of truncating them away.
+func TestRollbackMultiStepPreservesHistory(t *testing.T) {
+	store := NewInMemoryStore(nopLogger{})
+	key := ReleaseKey{Container: "svc", ReleaseChannel: "stable"}
+
+	for i := 1; i <= 5; i++ {
+		img := fmt.Sprintf("img-%d", i)
+		if err := store.Write(&Release{Container: key.Container, ReleaseChannel: key.ReleaseChannel, ImagePath: img}); err != nil {
+			t.Fatalf("write %s: %v", img, err)
+		}
+	}
+
+	target, err := store.Rollback(key, 2)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if target.ImagePath != "img-3" {
+		t.Fatalf("rollback restored %s, want img-3", target.ImagePath)
+	}
+
+	hist := store.History(key, 0)
+	got := make([]string, len(hist))
+	for i, r := range hist {
+		got[i] = r.ImagePath
+	}
+	want := []string{"img-5", "img-4", "img-2", "img-1"}
+	if len(got) != len(want) {
+		t.Fatalf("history = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("history = %v, want %v", got, want)
+		}
+	}
+}
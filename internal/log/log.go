@@ -0,0 +1,15 @@
+// Package log defines a minimal structured-logging interface so that
+// internal/db, internal/server, and cmd/server don't force every consumer
+// embedding these packages onto zerolog specifically.
+package log
+
+// Logger is a minimal structured logger. kv is a flat list of alternating
+// key/value pairs, mirroring log/slog's convention.
+type Logger interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+	// With returns a Logger that annotates every subsequent log line with kv.
+	With(kv ...any) Logger
+}
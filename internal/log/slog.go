@@ -0,0 +1,22 @@
+package log
+
+import "log/slog"
+
+// slogLogger adapts a log/slog.Logger to the Logger interface.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlog returns a Logger backed by l.
+func NewSlog(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+func (s *slogLogger) Debug(msg string, kv ...any) { s.l.Debug(msg, kv...) }
+func (s *slogLogger) Info(msg string, kv ...any)  { s.l.Info(msg, kv...) }
+func (s *slogLogger) Warn(msg string, kv ...any)  { s.l.Warn(msg, kv...) }
+func (s *slogLogger) Error(msg string, kv ...any) { s.l.Error(msg, kv...) }
+
+func (s *slogLogger) With(kv ...any) Logger {
+	return &slogLogger{l: s.l.With(kv...)}
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLoggerLevelsAndFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlog(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	l.Info("hello", "container", "svc")
+
+	out := buf.String()
+	if !strings.Contains(out, "hello") {
+		t.Fatalf("output %q missing message", out)
+	}
+	if !strings.Contains(out, "level=INFO") {
+		t.Fatalf("output %q missing info level", out)
+	}
+	if !strings.Contains(out, "container=svc") {
+		t.Fatalf("output %q missing kv pair", out)
+	}
+}
+
+func TestSlogLoggerWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlog(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	l2 := l.With("package", "db")
+	l2.Warn("reindexed")
+
+	out := buf.String()
+	if !strings.Contains(out, "package=db") {
+		t.Fatalf("output %q missing With field", out)
+	}
+	if !strings.Contains(out, "reindexed") {
+		t.Fatalf("output %q missing message", out)
+	}
+}
@@ -0,0 +1,37 @@
+package log
+
+import "github.com/rs/zerolog"
+
+// zerologLogger adapts a zerolog.Logger to the Logger interface. This is
+// the default adapter, preserving the server's prior logging behavior.
+type zerologLogger struct {
+	l zerolog.Logger
+}
+
+// NewZerolog returns a Logger backed by l.
+func NewZerolog(l zerolog.Logger) Logger {
+	return &zerologLogger{l: l}
+}
+
+func (z *zerologLogger) Debug(msg string, kv ...any) { withFields(z.l.Debug(), kv).Msg(msg) }
+func (z *zerologLogger) Info(msg string, kv ...any)  { withFields(z.l.Info(), kv).Msg(msg) }
+func (z *zerologLogger) Warn(msg string, kv ...any)  { withFields(z.l.Warn(), kv).Msg(msg) }
+func (z *zerologLogger) Error(msg string, kv ...any) { withFields(z.l.Error(), kv).Msg(msg) }
+
+func (z *zerologLogger) With(kv ...any) Logger {
+	ctx := z.l.With()
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, _ := kv[i].(string)
+		ctx = ctx.Interface(key, kv[i+1])
+	}
+	return &zerologLogger{l: ctx.Logger()}
+}
+
+// withFields attaches the flat key/value pairs in kv to a zerolog.Event.
+func withFields(e *zerolog.Event, kv []any) *zerolog.Event {
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, _ := kv[i].(string)
+		e = e.Interface(key, kv[i+1])
+	}
+	return e
+}
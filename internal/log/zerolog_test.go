@@ -0,0 +1,43 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestZerologLoggerLevelsAndFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZerolog(zerolog.New(&buf))
+
+	l.Error("write failed", "container", "svc")
+
+	out := buf.String()
+	if !strings.Contains(out, `"message":"write failed"`) {
+		t.Fatalf("output %q missing message", out)
+	}
+	if !strings.Contains(out, `"level":"error"`) {
+		t.Fatalf("output %q missing error level", out)
+	}
+	if !strings.Contains(out, `"container":"svc"`) {
+		t.Fatalf("output %q missing kv pair", out)
+	}
+}
+
+func TestZerologLoggerWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZerolog(zerolog.New(&buf))
+
+	l2 := l.With("package", "db")
+	l2.Info("reindexed")
+
+	out := buf.String()
+	if !strings.Contains(out, `"package":"db"`) {
+		t.Fatalf("output %q missing With field", out)
+	}
+	if !strings.Contains(out, `"message":"reindexed"`) {
+		t.Fatalf("output %q missing message", out)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/db"
+)
+
+// hub is a simple fan-out pub/sub used to push ReleaseEvent deltas to gRPC
+// Watch subscribers whenever loadDatabase reloads, instead of making them
+// re-poll Query.
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan *db.Release]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan *db.Release]struct{})}
+}
+
+// subscribe registers a new subscriber channel. Callers must call the
+// returned unsubscribe func when they're done listening.
+func (h *hub) subscribe() (ch chan *db.Release, unsubscribe func()) {
+	ch = make(chan *db.Release, 16)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish fans r out to every current subscriber, dropping it for any
+// subscriber whose buffer is full rather than blocking the reload path.
+func (h *hub) publish(r *db.Release) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- r:
+		default:
+		}
+	}
+}
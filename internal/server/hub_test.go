@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/myprizepicks/releasechannels-server-poc/internal/db"
+)
+
+func TestHubPublishFansOutToSubscribers(t *testing.T) {
+	h := newHub()
+
+	ch1, unsubscribe1 := h.subscribe()
+	defer unsubscribe1()
+	ch2, unsubscribe2 := h.subscribe()
+	defer unsubscribe2()
+
+	h.publish(&db.Release{Container: "svc", ReleaseChannel: "stable", ImagePath: "img-1"})
+
+	for _, ch := range []chan *db.Release{ch1, ch2} {
+		select {
+		case r := <-ch:
+			if r.ImagePath != "img-1" {
+				t.Fatalf("got %q, want img-1", r.ImagePath)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("subscriber never received the published release")
+		}
+	}
+}
+
+func TestHubPublishDropsOnFullBuffer(t *testing.T) {
+	h := newHub()
+	ch, unsubscribe := h.subscribe()
+	defer unsubscribe()
+
+	// Fill the subscriber's buffer, then publish once more; the extra
+	// publish must not block, and must be dropped rather than buffered.
+	for i := 0; i < cap(ch); i++ {
+		h.publish(&db.Release{Container: "svc", ReleaseChannel: "stable", ImagePath: "filler"})
+	}
+	h.publish(&db.Release{Container: "svc", ReleaseChannel: "stable", ImagePath: "dropped"})
+
+	for i := 0; i < cap(ch); i++ {
+		if r := <-ch; r.ImagePath != "filler" {
+			t.Fatalf("buffer holds %q, want only fillers", r.ImagePath)
+		}
+	}
+	if len(ch) != 0 {
+		t.Fatal("extra publish was buffered, want it dropped")
+	}
+}
+
+func TestHubUnsubscribeClosesChannel(t *testing.T) {
+	h := newHub()
+	ch, unsubscribe := h.subscribe()
+	unsubscribe()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed after unsubscribe")
+	}
+}
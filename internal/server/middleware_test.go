@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func unaryWriteInfo() *grpc.UnaryServerInfo {
+	return &grpc.UnaryServerInfo{FullMethod: writeFullMethod}
+}
+
+func noopHandler(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+func TestRequireClientCertUnaryPassesThroughNonWriteRPCs(t *testing.T) {
+	s := &srv{tlsConfig: &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.ReleaseChannels/Query"}
+
+	if _, err := s.requireClientCertUnary(context.Background(), nil, info, noopHandler); err != nil {
+		t.Fatalf("unexpected error for a non-Write RPC: %v", err)
+	}
+}
+
+func TestRequireClientCertUnaryAllowsWriteWhenMTLSDisabled(t *testing.T) {
+	s := &srv{}
+
+	if _, err := s.requireClientCertUnary(context.Background(), nil, unaryWriteInfo(), noopHandler); err != nil {
+		t.Fatalf("unexpected error with mTLS disabled: %v", err)
+	}
+}
+
+func TestRequireClientCertUnaryRejectsWriteWithoutPeer(t *testing.T) {
+	s := &srv{tlsConfig: &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}}
+
+	_, err := s.requireClientCertUnary(context.Background(), nil, unaryWriteInfo(), noopHandler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got error %v, want Unauthenticated", err)
+	}
+}
+
+func TestRequireClientCertUnaryRejectsWriteWithoutVerifiedChain(t *testing.T) {
+	s := &srv{tlsConfig: &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{}},
+	})
+
+	_, err := s.requireClientCertUnary(ctx, nil, unaryWriteInfo(), noopHandler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got error %v, want Unauthenticated", err)
+	}
+}
+
+func TestRequireClientCertUnaryAllowsWriteWithVerifiedChain(t *testing.T) {
+	s := &srv{tlsConfig: &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{}}},
+		}},
+	})
+
+	out, err := s.requireClientCertUnary(ctx, nil, unaryWriteInfo(), noopHandler)
+	if err != nil {
+		t.Fatalf("unexpected error with a verified chain: %v", err)
+	}
+	if out != "ok" {
+		t.Fatalf("got %v, want handler's result", out)
+	}
+}
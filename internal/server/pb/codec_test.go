@@ -0,0 +1,28 @@
+package pb
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// TestJSONCodecWinsTheProtoName guards the init-order invariant documented
+// on jsonCodec's registration: grpc-go's own "proto" codec (registered by
+// google.golang.org/grpc/codec.go, which this package imports) must lose to
+// ours, or every RPC in this service would start failing to marshal again.
+func TestJSONCodecWinsTheProtoName(t *testing.T) {
+	c := encoding.GetCodec("proto")
+
+	b, err := c.Marshal(&Release{Container: "x"})
+	if err != nil {
+		t.Fatalf("marshal failed (the real protobuf codec is active, not ours): %v", err)
+	}
+
+	var out Release
+	if err := c.Unmarshal(b, &out); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if out.Container != "x" {
+		t.Fatalf("got %q, want x", out.Container)
+	}
+}
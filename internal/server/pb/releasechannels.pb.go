@@ -0,0 +1,90 @@
+// Hand-maintained Go mirror of the messages in releasechannels.proto.
+// protoc/protoc-gen-go aren't part of this repo's build toolchain, so these
+// are NOT real protoc-gen-go output: they don't implement proto.Message,
+// and are carried over the wire by the JSON codec registered in codec.go
+// instead of real protobuf encoding. Keep these structs (and their json/
+// protobuf tags, kept for documentation and for a future real codegen
+// swap-in) in sync with releasechannels.proto by hand.
+
+package pb
+
+// QueryRequest is the same container/releaseChannel filter used by the
+// GET /v1/releases handler; either field may be left empty.
+type QueryRequest struct {
+	Container      string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	ReleaseChannel string `protobuf:"bytes,2,opt,name=release_channel,json=releaseChannel,proto3" json:"release_channel,omitempty"`
+}
+
+func (x *QueryRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetReleaseChannel() string {
+	if x != nil {
+		return x.ReleaseChannel
+	}
+	return ""
+}
+
+// Release mirrors db.Release.
+type Release struct {
+	Container      string `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	ImagePath      string `protobuf:"bytes,2,opt,name=image_path,json=imagePath,proto3" json:"image_path,omitempty"`
+	ReleaseChannel string `protobuf:"bytes,3,opt,name=release_channel,json=releaseChannel,proto3" json:"release_channel,omitempty"`
+}
+
+func (x *Release) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *Release) GetImagePath() string {
+	if x != nil {
+		return x.ImagePath
+	}
+	return ""
+}
+
+func (x *Release) GetReleaseChannel() string {
+	if x != nil {
+		return x.ReleaseChannel
+	}
+	return ""
+}
+
+// WriteResponse reports the outcome of a Write call.
+type WriteResponse struct {
+	Ok    bool   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *WriteResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *WriteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ReleaseEvent is a single reload-driven delta pushed to Watch subscribers.
+type ReleaseEvent struct {
+	Release *Release `protobuf:"bytes,1,opt,name=release,proto3" json:"release,omitempty"`
+}
+
+func (x *ReleaseEvent) GetRelease() *Release {
+	if x != nil {
+		return x.Release
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Hand-maintained Go mirror of the service in releasechannels.proto (see
+// releasechannels.pb.go for why this isn't real protoc-gen-go-grpc output).
+// Keep in sync with releasechannels.proto by hand.
+
+package pb
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+)
+
+// ReleaseChannelsServer is the server API for the ReleaseChannels service.
+type ReleaseChannelsServer interface {
+	// Query streams the releases matching the given filter.
+	Query(*QueryRequest, ReleaseChannels_QueryServer) error
+	// Write upserts a release.
+	Write(context.Context, *Release) (*WriteResponse, error)
+	// Watch streams a delta event every time ReloadDatabase publishes one.
+	Watch(*QueryRequest, ReleaseChannels_WatchServer) error
+}
+
+// UnimplementedReleaseChannelsServer can be embedded to have forward
+// compatible implementations.
+type UnimplementedReleaseChannelsServer struct{}
+
+func (UnimplementedReleaseChannelsServer) Query(*QueryRequest, ReleaseChannels_QueryServer) error {
+	return grpc.Errorf(12, "method Query not implemented")
+}
+func (UnimplementedReleaseChannelsServer) Write(context.Context, *Release) (*WriteResponse, error) {
+	return nil, grpc.Errorf(12, "method Write not implemented")
+}
+func (UnimplementedReleaseChannelsServer) Watch(*QueryRequest, ReleaseChannels_WatchServer) error {
+	return grpc.Errorf(12, "method Watch not implemented")
+}
+
+// ReleaseChannels_QueryServer is the server-streaming handle for Query.
+type ReleaseChannels_QueryServer interface {
+	Send(*Release) error
+	grpc.ServerStream
+}
+
+type releaseChannelsQueryServer struct {
+	grpc.ServerStream
+}
+
+func (s *releaseChannelsQueryServer) Send(r *Release) error {
+	return s.ServerStream.SendMsg(r)
+}
+
+// ReleaseChannels_WatchServer is the server-streaming handle for Watch.
+type ReleaseChannels_WatchServer interface {
+	Send(*ReleaseEvent) error
+	grpc.ServerStream
+}
+
+type releaseChannelsWatchServer struct {
+	grpc.ServerStream
+}
+
+func (s *releaseChannelsWatchServer) Send(e *ReleaseEvent) error {
+	return s.ServerStream.SendMsg(e)
+}
+
+// RegisterReleaseChannelsServer registers srv with s under the
+// ReleaseChannels service descriptor.
+func RegisterReleaseChannelsServer(s grpc.ServiceRegistrar, srv ReleaseChannelsServer) {
+	s.RegisterService(&releaseChannelsServiceDesc, srv)
+}
+
+func releaseChannelsQueryHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(QueryRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(ReleaseChannelsServer).Query(req, &releaseChannelsQueryServer{stream})
+}
+
+func releaseChannelsWriteHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Release)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReleaseChannelsServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/pb.ReleaseChannels/Write"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReleaseChannelsServer).Write(ctx, req.(*Release))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func releaseChannelsWatchHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(QueryRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(ReleaseChannelsServer).Watch(req, &releaseChannelsWatchServer{stream})
+}
+
+var releaseChannelsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.ReleaseChannels",
+	HandlerType: (*ReleaseChannelsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Write",
+			Handler:    releaseChannelsWriteHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Query",
+			Handler:       releaseChannelsQueryHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       releaseChannelsWatchHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "internal/server/pb/releasechannels.proto",
+}
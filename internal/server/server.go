@@ -2,22 +2,49 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/labstack/echo/v4"
 	"github.com/myprizepicks/releasechannels-server-poc/internal/db"
-	"github.com/rs/zerolog"
+	"github.com/myprizepicks/releasechannels-server-poc/internal/log"
+	"github.com/myprizepicks/releasechannels-server-poc/internal/server/pb"
+	"google.golang.org/grpc"
 )
 
+// fileWatchDebounce is how long startFileWatcher waits for an editor's burst
+// of write/rename events to settle before triggering a single reload.
+const fileWatchDebounce = 200 * time.Millisecond
+
+// fileWatchFallbackInterval is the poll interval used only when fsnotify
+// can't register a watch on this filesystem (e.g. some network mounts).
+const fileWatchFallbackInterval = 30 * time.Second
+
 // Server is the interface for a releasechan api server
 type Server interface {
 	Run(context.Context) error
 	ReloadDatabase() error
+	// RegisterGRPC registers the ReleaseChannels gRPC service backed by the
+	// same db.Store and hub as the HTTP server, so main can run both
+	// transports against one srv.
+	RegisterGRPC(grpc.ServiceRegistrar)
+	// SetTLSConfig enables HTTPS (and, if ClientAuth is set, mTLS) on the
+	// HTTP listener started by Run. It must be called before Run.
+	SetTLSConfig(cfg *tls.Config)
+	// UnaryInterceptor returns the grpc.UnaryServerInterceptor that enforces
+	// a verified client cert on the Write RPC, mirroring requireClientCert
+	// on the HTTP side. The caller installs it via grpc.UnaryInterceptor
+	// when building the gRPC server.
+	UnaryInterceptor() grpc.UnaryServerInterceptor
 }
 
 // srv implements Server
@@ -26,16 +53,28 @@ type srv struct {
 	dbFile    string
 	lastMod   time.Time
 	closeChan chan struct{}
-	ll        *zerolog.Logger
+	ll        log.Logger
+	hub       *hub
+	tlsConfig *tls.Config
 }
 
+// srv implements pb.ReleaseChannelsServer directly, so the gRPC surface
+// shares the same db.Store and hub as the REST handlers below.
+var _ pb.ReleaseChannelsServer = &srv{}
+
 // srv explicitly implements Server
 var _ Server = &srv{}
 
-// New is a constructor for an srv-backed Server
-func New(data []byte, dbFile string, gl *zerolog.Logger) (Server, error) {
-	ll := gl.With().Str("package", "server").Logger()
-	ll.Info().Int("bytes_in", len(data)).Msg("Server Started")
+// New is a constructor for an srv-backed Server. store is the already
+// constructed storage backend (see db.New / db.Register); the in-memory
+// driver is used if store is nil, preserving the old default behavior.
+func New(store db.Store, data []byte, dbFile string, gl log.Logger) (Server, error) {
+	ll := gl.With("package", "server")
+	ll.Info("Server Started", "bytes_in", len(data))
+
+	if store == nil {
+		store = db.NewInMemoryStore(gl)
+	}
 
 	// Get initial file mod time
 	var lastMod time.Time
@@ -45,26 +84,30 @@ func New(data []byte, dbFile string, gl *zerolog.Logger) (Server, error) {
 
 	// construct the srv
 	out := &srv{
-		db:        db.NewInMemoryStore(gl),
+		db:        store,
 		dbFile:    dbFile,
 		lastMod:   lastMod,
 		closeChan: make(chan struct{}),
-		ll:        &ll,
+		ll:        ll,
+		hub:       newHub(),
 	}
 
-	// Load initial data
-	if err := out.loadDatabase(data); err != nil {
-		return nil, err
+	// Only bulk-import the on-disk db.json into the store on startup if the
+	// chosen driver came up empty; a persistent driver (boltdb, configmap)
+	// that already holds data from a prior run should not be re-seeded.
+	if len(out.db.Query(&db.Release{})) == 0 {
+		if err := out.loadDatabase(data); err != nil {
+			return nil, err
+		}
+	} else {
+		out.ll.Info("storage driver already populated, skipping db.json bulk import")
 	}
 
 	return out, nil
 }
 
-// loadDatabase loads release data into the database
+// loadDatabase loads release data into the database.
 func (s *srv) loadDatabase(data []byte) error {
-	// Create new database instance
-	s.db = db.NewInMemoryStore(s.ll)
-
 	// marshal the test-data into a []db.Release
 	releasesIn := struct {
 		Releases db.Releases `json:"releases"`
@@ -72,7 +115,7 @@ func (s *srv) loadDatabase(data []byte) error {
 		Releases: db.Releases{},
 	}
 	if err := json.Unmarshal(data, &releasesIn); err != nil {
-		s.ll.Error().Err(err).Msg("error unmarshalling input")
+		s.ll.Error("error unmarshalling input", "error", err)
 		return err
 	}
 
@@ -80,10 +123,14 @@ func (s *srv) loadDatabase(data []byte) error {
 	for _, r := range releasesIn.Releases {
 		err := s.db.Write(r)
 		if err != nil {
-			s.ll.Error().Err(err)
+			s.ll.Error("error writing release", "error", err)
+			continue
 		}
+		// Fan the write out to any gRPC Watch subscribers as a delta
+		// instead of making them re-poll Query.
+		s.hub.publish(r)
 	}
-	s.ll.Info().Int("releases_loaded", len(releasesIn.Releases)).Msg("database loaded")
+	s.ll.Info("database loaded", "releases_loaded", len(releasesIn.Releases))
 	return nil
 }
 
@@ -92,7 +139,7 @@ func (s *srv) ReloadDatabase() error {
 	// Check if file has been modified
 	stat, err := os.Stat(s.dbFile)
 	if err != nil {
-		s.ll.Error().Err(err).Str("file", s.dbFile).Msg("error checking file stat")
+		s.ll.Error("error checking file stat", "error", err, "file", s.dbFile)
 		return err
 	}
 
@@ -101,73 +148,188 @@ func (s *srv) ReloadDatabase() error {
 		return nil
 	}
 
-	s.ll.Info().Time("old_mod_time", s.lastMod).Time("new_mod_time", stat.ModTime()).Msg("file changed, reloading database")
+	s.ll.Info("file changed, reloading database", "old_mod_time", s.lastMod, "new_mod_time", stat.ModTime())
 
 	// Read the file
 	data, err := os.ReadFile(s.dbFile)
 	if err != nil {
-		s.ll.Error().Err(err).Str("file", s.dbFile).Msg("error reading database file")
+		s.ll.Error("error reading database file", "error", err, "file", s.dbFile)
 		return err
 	}
 
 	// Load the new data
 	if err := s.loadDatabase(data); err != nil {
-		s.ll.Error().Err(err).Msg("error loading new database")
+		s.ll.Error("error loading new database", "error", err)
 		return err
 	}
 
 	// Update last modified time
 	s.lastMod = stat.ModTime()
-	s.ll.Info().Msg("database reloaded successfully")
+	s.ll.Info("database reloaded successfully")
 	return nil
 }
 
-// startFileWatcher starts a background goroutine to watch for file changes
+// startFileWatcher starts a background goroutine to watch for changes to
+// dbFile via fsnotify, debouncing bursts of events before reloading. If
+// fsnotify can't register a watch on this filesystem, it falls back to
+// polling os.Stat on a ticker so the server still picks up changes.
 func (s *srv) startFileWatcher(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.ll.Error("error creating fsnotify watcher, falling back to polling", "error", err)
+		s.startPollingFileWatcher(ctx)
+		return
+	}
+
+	// Watch the parent directory rather than dbFile itself, so atomic-rename
+	// editors (which replace dbFile rather than writing in place) still
+	// trigger a reload; events for other files in it are skipped below.
+	dir := filepath.Dir(s.dbFile)
+	if err := watcher.Add(dir); err != nil {
+		s.ll.Error("error watching db directory, falling back to polling", "error", err, "dir", dir)
+		watcher.Close()
+		s.startPollingFileWatcher(ctx)
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce *time.Timer
+		reload := func() {
+			if err := s.ReloadDatabase(); err != nil {
+				s.ll.Error("error during automatic database reload", "error", err)
+			}
+		}
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(s.dbFile) {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if debounce == nil {
+					debounce = time.AfterFunc(fileWatchDebounce, reload)
+				} else {
+					debounce.Reset(fileWatchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.ll.Error("fsnotify watcher error", "error", err)
+			case <-ctx.Done():
+				if debounce != nil {
+					debounce.Stop()
+				}
+				s.ll.Info("file watcher stopping")
+				return
+			}
+		}
+	}()
+	s.ll.Info("fsnotify file watcher started", "file", s.dbFile)
+}
+
+// startPollingFileWatcher is the fallback startFileWatcher switches to on
+// its own when fsnotify fails to register a watch; it polls os.Stat on a
+// ticker as the old code did.
+func (s *srv) startPollingFileWatcher(ctx context.Context) {
+	ticker := time.NewTicker(fileWatchFallbackInterval)
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
 				if err := s.ReloadDatabase(); err != nil {
-					s.ll.Error().Err(err).Msg("error during automatic database reload")
+					s.ll.Error("error during automatic database reload", "error", err)
+				}
+			case <-ctx.Done():
+				s.ll.Info("file watcher stopping")
+				return
+			}
+		}
+	}()
+	s.ll.Info("polling file watcher started")
+}
+
+// startSignalHandler installs a signal handler that triggers a database
+// reload on SIGHUP. SIGTERM/SIGINT are handled by main via the shared root
+// context (see cmd/server), which Run and runGRPC both select on directly,
+// so shutdown runs through the normal ctx.Done() path for both transports.
+func (s *srv) startSignalHandler(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-sigChan:
+				s.ll.Info("SIGHUP received, reloading database")
+				if err := s.ReloadDatabase(); err != nil {
+					s.ll.Error("error reloading database on SIGHUP", "error", err)
 				}
 			case <-ctx.Done():
-				s.ll.Info().Msg("file watcher stopping")
 				return
 			}
 		}
 	}()
-	s.ll.Info().Msg("file watcher started")
 }
 
-// Run is a blocking function to run the server in an errgroup
+// SetTLSConfig enables HTTPS (and, if ClientAuth is set, mTLS) on the HTTP
+// listener started by Run. It must be called before Run.
+func (s *srv) SetTLSConfig(cfg *tls.Config) {
+	s.tlsConfig = cfg
+}
+
+// Run is a blocking function to run the server in an errgroup. ctx is the
+// shared root context owned by main; Run never derives its own cancelable
+// child so a cancel here (or a sibling failure in the errgroup) always
+// propagates to every other server sharing ctx, notably runGRPC.
 func (s *srv) Run(ctx context.Context) error {
-	// Start file watcher
+	// Start the file watcher and the SIGHUP reload handler
 	s.startFileWatcher(ctx)
+	s.startSignalHandler(ctx)
 
 	// start a new echo server
 	e := echo.New()
+	e.Use(clientCertMiddleware)
 
 	// register endpoints
 	e.GET("/v1/releases", s.queryHandler)
+	e.GET("/v1/releases/history", s.historyHandler)
 	e.GET("/v1/ready", s.readyHandler)
 	e.GET("/v1/health", s.healthHandler)
-	e.POST("/v1/reload", s.reloadHandler) // Manual reload endpoint
+	e.POST("/v1/reload", s.reloadHandler, s.requireClientCert)             // Manual reload endpoint
+	e.POST("/v1/releases/rollback", s.rollbackHandler, s.requireClientCert) // Roll a channel back to a prior release
 
 	// Create a server so we have a shutdown method
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", 8089),
-		Handler: e,
+		Addr:      fmt.Sprintf(":%d", 8089),
+		Handler:   e,
+		TLSConfig: s.tlsConfig,
 	}
 
 	// Background the server
 	go func() {
-		s.ll.Info().Msg("server listening on port 8089")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.tlsConfig != nil {
+			s.ll.Info("server listening on port 8089 (tls)")
+			// Certificates are already loaded on TLSConfig, so certFile/keyFile are empty.
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			s.ll.Info("server listening on port 8089")
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			// something happened in the server, log the error and signal a fatal stop
-			s.ll.Error().Err(err).Msg("early exit from server")
+			s.ll.Error("early exit from server", "error", err)
 			close(s.closeChan)
 		}
 	}()
@@ -176,7 +338,7 @@ func (s *srv) Run(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			s.ll.Info().Msg("api exiting")
+			s.ll.Info("api exiting")
 			server.Shutdown(context.Background())
 			return nil
 		case <-s.closeChan:
@@ -199,6 +361,23 @@ func (s *srv) queryHandler(c echo.Context) error {
 	return c.JSON(http.StatusNotFound, resp)
 }
 
+// historyHandler responds with the prior values of a release channel, so
+// operators can see what a channel used to resolve to.
+func (s *srv) historyHandler(c echo.Context) error {
+	container := c.QueryParam("container")
+	releaseChannel := c.QueryParam("releaseChannel")
+	if container == "" || releaseChannel == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "container and releaseChannel are required"})
+	}
+
+	key := db.ReleaseKey{Container: container, ReleaseChannel: releaseChannel}
+	resp := s.db.History(key, 0)
+	if len(resp) > 0 {
+		return c.JSON(http.StatusOK, resp)
+	}
+	return c.JSON(http.StatusNotFound, resp)
+}
+
 func (s *srv) readyHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, "{200: ready}")
 }
@@ -209,9 +388,117 @@ func (s *srv) healthHandler(c echo.Context) error {
 
 // reloadHandler manually triggers a database reload
 func (s *srv) reloadHandler(c echo.Context) error {
+	if cn, ok := c.Get(ctxKeyClientCN).(string); ok && cn != "" {
+		s.ll.Info("manual reload requested", "client_cn", cn)
+	}
 	if err := s.ReloadDatabase(); err != nil {
-		s.ll.Error().Err(err).Msg("manual reload failed")
+		s.ll.Error("manual reload failed", "error", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"status": "reloaded"})
 }
+
+// rollbackRequest is the POST /v1/releases/rollback body.
+type rollbackRequest struct {
+	Container      string `json:"container"`
+	ReleaseChannel string `json:"releaseChannel"`
+	Steps          int    `json:"steps"`
+}
+
+// rollbackHandler rolls a release channel back to a prior value.
+func (s *srv) rollbackHandler(c echo.Context) error {
+	req := &rollbackRequest{Steps: 1}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Container == "" || req.ReleaseChannel == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "container and releaseChannel are required"})
+	}
+
+	if cn, ok := c.Get(ctxKeyClientCN).(string); ok && cn != "" {
+		s.ll.Info("rollback requested", "client_cn", cn, "container", req.Container, "releaseChannel", req.ReleaseChannel)
+	}
+
+	key := db.ReleaseKey{Container: req.Container, ReleaseChannel: req.ReleaseChannel}
+	r, err := s.db.Rollback(key, req.Steps)
+	if err != nil {
+		s.ll.Error("rollback failed", "error", err, "key", key.String())
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	s.hub.publish(r)
+	return c.JSON(http.StatusOK, r)
+}
+
+// RegisterGRPC registers s as the ReleaseChannels gRPC service on gs.
+func (s *srv) RegisterGRPC(gs grpc.ServiceRegistrar) {
+	pb.RegisterReleaseChannelsServer(gs, s)
+}
+
+// UnaryInterceptor returns the grpc.UnaryServerInterceptor that enforces a
+// verified client cert on the Write RPC.
+func (s *srv) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return s.requireClientCertUnary
+}
+
+// Query streams the releases matching req to the client.
+func (s *srv) Query(req *pb.QueryRequest, stream pb.ReleaseChannels_QueryServer) error {
+	resp := s.db.Query(&db.Release{Container: req.GetContainer(), ReleaseChannel: req.GetReleaseChannel()})
+	for _, r := range resp {
+		if err := stream.Send(toPBRelease(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write stores a release via the gRPC transport as the current value for
+// its container and release channel.
+func (s *srv) Write(ctx context.Context, r *pb.Release) (*pb.WriteResponse, error) {
+	release := &db.Release{
+		Container:      r.GetContainer(),
+		ImagePath:      r.GetImagePath(),
+		ReleaseChannel: r.GetReleaseChannel(),
+	}
+	if err := s.db.Write(release); err != nil {
+		return &pb.WriteResponse{Ok: false, Error: err.Error()}, nil
+	}
+	s.hub.publish(release)
+	return &pb.WriteResponse{Ok: true}, nil
+}
+
+// Watch streams a ReleaseEvent to the client every time loadDatabase
+// publishes a delta that matches req's filter.
+func (s *srv) Watch(req *pb.QueryRequest, stream pb.ReleaseChannels_WatchServer) error {
+	ch, unsubscribe := s.hub.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if req.GetContainer() != "" && r.Container != req.GetContainer() {
+				continue
+			}
+			if req.GetReleaseChannel() != "" && r.ReleaseChannel != req.GetReleaseChannel() {
+				continue
+			}
+			if err := stream.Send(&pb.ReleaseEvent{Release: toPBRelease(r)}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// toPBRelease converts a db.Release to its gRPC wire representation.
+func toPBRelease(r *db.Release) *pb.Release {
+	return &pb.Release{
+		Container:      r.Container,
+		ImagePath:      r.ImagePath,
+		ReleaseChannel: r.ReleaseChannel,
+	}
+}
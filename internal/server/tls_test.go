@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedKeypair generates a self-signed cert/key pair and writes
+// both the cert and key as PEM files under dir, returning their paths. The
+// cert doubles as its own CA for NewTLSConfig's caFile argument.
+func writeSelfSignedKeypair(t *testing.T, dir, prefix string) (certPath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: prefix},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+
+	certPath = filepath.Join(dir, prefix+"-cert.pem")
+	keyPath = filepath.Join(dir, prefix+"-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing cert: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestNewTLSConfigWithoutClientVerification(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSignedKeypair(t, dir, "server")
+
+	cfg, err := NewTLSConfig(certPath, keyPath, "", false)
+	if err != nil {
+		t.Fatalf("NewTLSConfig: %v", err)
+	}
+	if len(cfg.Certificates) != 1 {
+		t.Fatalf("got %d certificates, want 1", len(cfg.Certificates))
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Fatalf("ClientAuth = %v, want NoClientCert", cfg.ClientAuth)
+	}
+}
+
+func TestNewTLSConfigWithClientVerification(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSignedKeypair(t, dir, "server")
+	caPath, _ := writeSelfSignedKeypair(t, dir, "ca")
+
+	cfg, err := NewTLSConfig(certPath, keyPath, caPath, true)
+	if err != nil {
+		t.Fatalf("NewTLSConfig: %v", err)
+	}
+	if cfg.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Fatalf("ClientAuth = %v, want VerifyClientCertIfGiven", cfg.ClientAuth)
+	}
+	if cfg.ClientCAs == nil {
+		t.Fatal("expected ClientCAs to be populated")
+	}
+}
+
+func TestNewTLSConfigMissingCA(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSignedKeypair(t, dir, "server")
+
+	if _, err := NewTLSConfig(certPath, keyPath, filepath.Join(dir, "missing-ca.pem"), true); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+}
+
+func TestNewTLSConfigMissingKeypair(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewTLSConfig(filepath.Join(dir, "missing-cert.pem"), filepath.Join(dir, "missing-key.pem"), "", false); err == nil {
+		t.Fatal("expected an error for a missing cert/key pair")
+	}
+}